@@ -0,0 +1,36 @@
+package config
+
+// StoreTransactionMaxRetries controls how many times a MessageStore retries a
+// transactional write (e.g. SaveMessageAndIncrNextSenderMsgSeqNum) after a transient
+// error such as a SQLite BUSY, a MySQL deadlock, or a Postgres serialization failure,
+// before giving up.
+const StoreTransactionMaxRetries = "StoreTransactionMaxRetries"
+
+// StoreOperationTimeout bounds how long a session will wait on a single MessageStore
+// operation (SaveMessage, GetMessages, a seqnum update, ...) before giving up via the
+// Ctx variants of the MessageStore interface. A zero or missing value means no
+// deadline is applied.
+const StoreOperationTimeout = "StoreOperationTimeout"
+
+// MessageStoreType selects the MessageStoreFactory driver to build for a session
+// (e.g. "memory", "file", "mongo", "gorm", or "sql"), dispatched via whatever was
+// registered with RegisterMessageStoreDriver under that name.
+const MessageStoreType = "MessageStoreType"
+
+// GormStoreDriver is the SQL dialect to open for the "gorm"/"sql" MessageStoreType
+// driver: "sqlite", "mysql", or "postgres".
+const GormStoreDriver = "GormStoreDriver"
+
+// GormStoreDataSourceName is the data source name passed to the dialect named by
+// GormStoreDriver.
+const GormStoreDataSourceName = "GormStoreDataSourceName"
+
+// StoreHealthProbeInterval is the number of seconds between periodic store health
+// probes when a StoreObserver is configured. A zero or missing value disables probing.
+const StoreHealthProbeInterval = "StoreHealthProbeInterval"
+
+// StoreReplayTimeout bounds how long a session will wait on a single resend replay
+// (resendRange's IterateMessagesCtx call) as a whole, as opposed to
+// StoreOperationTimeout which bounds one MessageStore call at a time. A zero or
+// missing value means no deadline is applied to the replay.
+const StoreReplayTimeout = "StoreReplayTimeout"
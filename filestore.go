@@ -0,0 +1,400 @@
+package quickfix
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quickfixgo/quickfix/config"
+)
+
+type fileStoreFactory struct {
+	settings *Settings
+}
+
+// NewFileStoreFactory returns a MessageStoreFactory backed by a directory of flat
+// files, one set per session, rooted at the FileStorePath session/global setting.
+func NewFileStoreFactory(settings *Settings) MessageStoreFactory {
+	return fileStoreFactory{settings: settings}
+}
+
+func (f fileStoreFactory) Create(sessionID SessionID) (MessageStore, error) {
+	sessionSettings, ok := f.settings.SessionSettings()[sessionID]
+	if !ok {
+		sessionSettings = f.settings.GlobalSettings()
+	}
+	dirname, err := sessionSettings.Setting(config.FileStorePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, "fileStoreFactory.Create err")
+	}
+	return newFileStore(dirname, sessionID)
+}
+
+// fileMsgDef locates a single stored message within the body log.
+type fileMsgDef struct {
+	offset int64
+	size   int
+}
+
+type fileStore struct {
+	sessionID   SessionID
+	cache       *memoryStore
+	index       map[int]fileMsgDef
+	bodyFile    *os.File
+	headerFile  *os.File
+	sessionFile *os.File
+}
+
+func fileStorePaths(dirname string, sessionID SessionID) (sessionFname, bodyFname, headerFname string) {
+	sessionPrefix := sessionID.String()
+	sessionPrefix = strings.ReplaceAll(sessionPrefix, ":", "-")
+	return path.Join(dirname, fmt.Sprintf("%s.session", sessionPrefix)),
+		path.Join(dirname, fmt.Sprintf("%s.body", sessionPrefix)),
+		path.Join(dirname, fmt.Sprintf("%s.header", sessionPrefix))
+}
+
+func newFileStore(dirname string, sessionID SessionID) (*fileStore, error) {
+	sessionFname, bodyFname, headerFname := fileStorePaths(dirname, sessionID)
+
+	store := &fileStore{
+		sessionID: sessionID,
+		cache:     &memoryStore{},
+		index:     make(map[int]fileMsgDef),
+	}
+	if err := store.cache.Reset(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if store.bodyFile, err = os.OpenFile(bodyFname, os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, errors.Wrap(err, "fileStore.newFileStore err")
+	}
+	if store.headerFile, err = os.OpenFile(headerFname, os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, errors.Wrap(err, "fileStore.newFileStore err")
+	}
+	if store.sessionFile, err = os.OpenFile(sessionFname, os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, errors.Wrap(err, "fileStore.newFileStore err")
+	}
+
+	if err := store.loadIndex(); err != nil {
+		return nil, err
+	}
+	if err := store.populateCache(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// loadIndex rebuilds the in-memory seqnum->offset index from the header file, so a
+// restart can resume appending to the body file without rewriting it.
+func (store *fileStore) loadIndex() error {
+	if _, err := store.headerFile.Seek(0, 0); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(store.headerFile)
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), ",")
+		if len(parts) != 3 {
+			continue
+		}
+		seqNum, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return err
+		}
+		offset, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		size, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return err
+		}
+		store.index[seqNum] = fileMsgDef{offset: offset, size: size}
+	}
+	return scanner.Err()
+}
+
+func (store *fileStore) populateCache() error {
+	if _, err := store.sessionFile.Seek(0, 0); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(store.sessionFile)
+	if !scanner.Scan() {
+		return store.writeSessionFile()
+	}
+	parts := strings.Split(scanner.Text(), ",")
+	if len(parts) != 3 {
+		return store.writeSessionFile()
+	}
+	creationTime, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return err
+	}
+	senderSeqNum, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+	targetSeqNum, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return err
+	}
+	store.cache.creationTime = creationTime
+	if err := store.cache.SetNextSenderMsgSeqNum(senderSeqNum); err != nil {
+		return err
+	}
+	return store.cache.SetNextTargetMsgSeqNum(targetSeqNum)
+}
+
+// writeSessionFile persists the cached creation time and both seqnums, overwriting
+// whatever was there before.
+func (store *fileStore) writeSessionFile() error {
+	if err := store.sessionFile.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := store.sessionFile.Seek(0, 0); err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s,%d,%d\n", store.cache.CreationTime().Format(time.RFC3339Nano),
+		store.cache.NextSenderMsgSeqNum(), store.cache.NextTargetMsgSeqNum())
+	if _, err := store.sessionFile.WriteString(line); err != nil {
+		return err
+	}
+	return store.sessionFile.Sync()
+}
+
+// NextSenderMsgSeqNum returns the next MsgSeqNum that will be sent
+func (store *fileStore) NextSenderMsgSeqNum() int {
+	return store.cache.NextSenderMsgSeqNum()
+}
+
+// NextTargetMsgSeqNum returns the next MsgSeqNum that should be received
+func (store *fileStore) NextTargetMsgSeqNum() int {
+	return store.cache.NextTargetMsgSeqNum()
+}
+
+// SetNextSenderMsgSeqNum sets the next MsgSeqNum that will be sent
+func (store *fileStore) SetNextSenderMsgSeqNum(next int) error {
+	if err := store.cache.SetNextSenderMsgSeqNum(next); err != nil {
+		return err
+	}
+	return store.writeSessionFile()
+}
+
+// SetNextTargetMsgSeqNum sets the next MsgSeqNum that should be received
+func (store *fileStore) SetNextTargetMsgSeqNum(next int) error {
+	if err := store.cache.SetNextTargetMsgSeqNum(next); err != nil {
+		return err
+	}
+	return store.writeSessionFile()
+}
+
+// IncrNextSenderMsgSeqNum increments the next MsgSeqNum that will be sent
+func (store *fileStore) IncrNextSenderMsgSeqNum() error {
+	if err := store.cache.IncrNextSenderMsgSeqNum(); err != nil {
+		return errors.Wrap(err, "cache incr next")
+	}
+	return store.SetNextSenderMsgSeqNum(store.cache.NextSenderMsgSeqNum())
+}
+
+// IncrNextTargetMsgSeqNum increments the next MsgSeqNum that should be received
+func (store *fileStore) IncrNextTargetMsgSeqNum() error {
+	if err := store.cache.IncrNextTargetMsgSeqNum(); err != nil {
+		return errors.Wrap(err, "cache incr next")
+	}
+	return store.SetNextTargetMsgSeqNum(store.cache.NextTargetMsgSeqNum())
+}
+
+// CreationTime returns the creation time of the store
+func (store *fileStore) CreationTime() time.Time {
+	return store.cache.CreationTime()
+}
+
+// SetCreationTime overrides the store's creation time, e.g. to preserve it across a
+// migration to a different MessageStore backend.
+func (store *fileStore) SetCreationTime(t time.Time) error {
+	if err := store.cache.SetCreationTime(t); err != nil {
+		return err
+	}
+	return store.writeSessionFile()
+}
+
+func (store *fileStore) SaveMessage(seqNum int, msg []byte) error {
+	offset, err := store.bodyFile.Seek(0, os.SEEK_END)
+	if err != nil {
+		return err
+	}
+	if _, err := store.bodyFile.Write(msg); err != nil {
+		return err
+	}
+	if err := store.bodyFile.Sync(); err != nil {
+		return err
+	}
+
+	if _, err := store.headerFile.WriteString(fmt.Sprintf("%d,%d,%d\n", seqNum, offset, len(msg))); err != nil {
+		return err
+	}
+	if err := store.headerFile.Sync(); err != nil {
+		return err
+	}
+
+	store.index[seqNum] = fileMsgDef{offset: offset, size: len(msg)}
+	return nil
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNum appends msg to the body/header log and only
+// then advances the outgoing seqnum in the session file, so a crash between the two
+// leaves the log ahead of the seqnum (safely replayable) rather than the reverse.
+func (store *fileStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	if err := store.SaveMessage(seqNum, msg); err != nil {
+		return err
+	}
+	return store.SetNextSenderMsgSeqNum(seqNum + 1)
+}
+
+func (store *fileStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	err := store.IterateMessages(beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		msgs = append(msgs, msg)
+		return nil
+	})
+	return msgs, err
+}
+
+// IterateMessages reads each stored message in [beginSeqNum, endSeqNum] sequentially
+// from the body file via its index entry, handing each one to fn as it's read rather
+// than buffering the whole range.
+func (store *fileStore) IterateMessages(beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	for seqNum := beginSeqNum; seqNum <= endSeqNum; seqNum++ {
+		def, ok := store.index[seqNum]
+		if !ok {
+			continue
+		}
+		msg := make([]byte, def.size)
+		if _, err := store.bodyFile.ReadAt(msg, def.offset); err != nil {
+			return err
+		}
+		if err := fn(seqNum, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset deletes the store records and sets the seqnums back to 1
+func (store *fileStore) Reset() error {
+	if err := store.cache.Reset(); err != nil {
+		return err
+	}
+	store.index = make(map[int]fileMsgDef)
+
+	if err := store.bodyFile.Truncate(0); err != nil {
+		return err
+	}
+	if err := store.headerFile.Truncate(0); err != nil {
+		return err
+	}
+	return store.writeSessionFile()
+}
+
+// Refresh reloads the store from the on-disk files
+func (store *fileStore) Refresh() error {
+	if err := store.cache.Reset(); err != nil {
+		return err
+	}
+	store.index = make(map[int]fileMsgDef)
+	if err := store.loadIndex(); err != nil {
+		return err
+	}
+	return store.populateCache()
+}
+
+// Close closes the store's open file handles
+func (store *fileStore) Close() error {
+	for _, f := range []*os.File{store.bodyFile, store.headerFile, store.sessionFile} {
+		if f != nil {
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The Ctx variants below check ctx once before starting the operation
+// (IterateMessagesCtx checks it again after reading each message, before handing it to
+// fn). A disk read or write that has already started is not interrupted.
+
+func (store *fileStore) SaveMessageCtx(ctx context.Context, seqNum int, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SaveMessage(seqNum, msg)
+}
+
+func (store *fileStore) SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx context.Context, seqNum int, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SaveMessageAndIncrNextSenderMsgSeqNum(seqNum, msg)
+}
+
+func (store *fileStore) GetMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return store.GetMessages(beginSeqNum, endSeqNum)
+}
+
+func (store *fileStore) IterateMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return store.IterateMessages(beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(seqNum, msg)
+	})
+}
+
+func (store *fileStore) ResetCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.Reset()
+}
+
+func (store *fileStore) RefreshCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.Refresh()
+}
+
+func (store *fileStore) SetNextSenderMsgSeqNumCtx(ctx context.Context, next int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetNextSenderMsgSeqNum(next)
+}
+
+func (store *fileStore) SetNextTargetMsgSeqNumCtx(ctx context.Context, next int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetNextTargetMsgSeqNum(next)
+}
+
+func (store *fileStore) SetCreationTimeCtx(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetCreationTime(t)
+}
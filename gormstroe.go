@@ -1,7 +1,9 @@
 package quickfix
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -10,6 +12,9 @@ import (
 	"github.com/quickfixgo/quickfix/config"
 )
 
+// defaultStoreTxnMaxRetries is used when config.StoreTransactionMaxRetries is not set.
+const defaultStoreTxnMaxRetries = 3
+
 type gormStoreFactory struct {
 	settings *Settings
 	db       *gorm.DB
@@ -20,9 +25,10 @@ func NewGormStoreFactory(settings *Settings, db *gorm.DB) MessageStoreFactory {
 }
 
 type gromStore struct {
-	sessionID SessionID
-	cache     *memoryStore
-	db        *gorm.DB
+	sessionID     SessionID
+	cache         *memoryStore
+	db            *gorm.DB
+	txnMaxRetries int
 }
 
 func (f gormStoreFactory) Create(sessionID SessionID) (msgStore MessageStore, err error) {
@@ -37,10 +43,20 @@ func (f gormStoreFactory) Create(sessionID SessionID) (msgStore MessageStore, er
 		return nil, fmt.Errorf("unknown session: %v", sessionID)
 	}
 
+	txnMaxRetries := defaultStoreTxnMaxRetries
+	if f.settings.GlobalSettings().HasSetting(config.StoreTransactionMaxRetries) {
+		n, err := f.settings.globalSettings.IntSetting(config.StoreTransactionMaxRetries)
+		if err != nil {
+			return nil, err
+		}
+		txnMaxRetries = n
+	}
+
 	store := &gromStore{
-		sessionID: sessionID,
-		cache:     &memoryStore{},
-		db:        f.db,
+		sessionID:     sessionID,
+		cache:         &memoryStore{},
+		db:            f.db,
+		txnMaxRetries: txnMaxRetries,
 	}
 	err = store.initTables()
 	if err != nil {
@@ -51,13 +67,24 @@ func (f gormStoreFactory) Create(sessionID SessionID) (msgStore MessageStore, er
 		err = errors.Wrap(err, "cache reset")
 		return
 	}
-	if err = store.populateCache(); err != nil {
+	if err = store.populateCache(context.Background()); err != nil {
 		return nil, err
 	}
+
 	return store, nil
 
 }
 
+// Ping reports whether the underlying database connection is reachable within ctx. It
+// is how WithStoreObserver health-probes a gromStore.
+func (store *gromStore) Ping(ctx context.Context) error {
+	sqlDB, err := store.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func (store *gromStore) initTables() (err error) {
 	if !store.db.Migrator().HasTable("sessions") {
 		err = store.db.Migrator().CreateTable(&GormSessions{})
@@ -76,8 +103,15 @@ func (store *gromStore) initTables() (err error) {
 
 // Reset deletes the store records and sets the seqnums back to 1
 func (store *gromStore) Reset() error {
+	return store.ResetCtx(context.Background())
+}
+
+// ResetCtx deletes the store records and sets the seqnums back to 1. The context
+// bounds how long the underlying SQL is allowed to run.
+func (store *gromStore) ResetCtx(ctx context.Context) error {
+	db := store.db.WithContext(ctx)
 	s := store.sessionID
-	err := store.db.Exec(`DELETE FROM messages
+	err := db.Exec(`DELETE FROM messages
 	WHERE beginstring=? AND session_qualifier=?
 	AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
@@ -89,7 +123,7 @@ func (store *gromStore) Reset() error {
 	if err = store.cache.Reset(); err != nil {
 		return err
 	}
-	err = store.db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+	err = db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
 	AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
 		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
@@ -103,16 +137,22 @@ func (store *gromStore) Reset() error {
 
 // Refresh reloads the store from the database
 func (store *gromStore) Refresh() error {
+	return store.RefreshCtx(context.Background())
+}
+
+// RefreshCtx reloads the store from the database, bounding the query by ctx.
+func (store *gromStore) RefreshCtx(ctx context.Context) error {
 	if err := store.cache.Reset(); err != nil {
 		return err
 	}
-	return store.populateCache()
+	return store.populateCache(ctx)
 }
 
-func (store *gromStore) populateCache() error {
+func (store *gromStore) populateCache(ctx context.Context) error {
+	db := store.db.WithContext(ctx)
 	dest := GormSessions{}
 	s := store.sessionID
-	err := store.db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+	err := db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
 	  AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	  AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
 		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
@@ -128,7 +168,7 @@ func (store *gromStore) populateCache() error {
 		return nil
 	}
 	if err == gorm.ErrRecordNotFound {
-		return store.db.Exec(`INSERT INTO sessions (
+		return db.Exec(`INSERT INTO sessions (
 			creation_time, incoming_seqnum, outgoing_seqnum,
 			beginstring, session_qualifier,
 			sendercompid, sendersubid, senderlocid,
@@ -155,9 +195,15 @@ func (store *gromStore) NextTargetMsgSeqNum() int {
 
 // SetNextSenderMsgSeqNum sets the next MsgSeqNum that will be sent
 func (store *gromStore) SetNextSenderMsgSeqNum(next int) error {
+	return store.SetNextSenderMsgSeqNumCtx(context.Background(), next)
+}
+
+// SetNextSenderMsgSeqNumCtx sets the next MsgSeqNum that will be sent, bounding the
+// update by ctx.
+func (store *gromStore) SetNextSenderMsgSeqNumCtx(ctx context.Context, next int) error {
 	s := store.sessionID
 
-	err := store.db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+	err := store.db.WithContext(ctx).Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
 	AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
 		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
@@ -170,9 +216,15 @@ func (store *gromStore) SetNextSenderMsgSeqNum(next int) error {
 
 // SetNextTargetMsgSeqNum sets the next MsgSeqNum that should be received
 func (store *gromStore) SetNextTargetMsgSeqNum(next int) error {
+	return store.SetNextTargetMsgSeqNumCtx(context.Background(), next)
+}
+
+// SetNextTargetMsgSeqNumCtx sets the next MsgSeqNum that should be received, bounding
+// the update by ctx.
+func (store *gromStore) SetNextTargetMsgSeqNumCtx(ctx context.Context, next int) error {
 	s := store.sessionID
 
-	err := store.db.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+	err := store.db.WithContext(ctx).Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
 	AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
 		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
@@ -204,14 +256,42 @@ func (store *gromStore) CreationTime() time.Time {
 	return store.cache.CreationTime()
 }
 
+// SetCreationTime overrides the store's creation time, e.g. to preserve it across a
+// migration to a different MessageStore backend.
+func (store *gromStore) SetCreationTime(t time.Time) error {
+	return store.SetCreationTimeCtx(context.Background(), t)
+}
+
+// SetCreationTimeCtx overrides the store's creation time, bounding the update by ctx.
+func (store *gromStore) SetCreationTimeCtx(ctx context.Context, t time.Time) error {
+	s := store.sessionID
+
+	err := store.db.WithContext(ctx).Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+	AND sendercompid=? AND sendersubid=? AND senderlocid=?
+	AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
+		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
+		s.TargetCompID, s.TargetSubID, s.TargetLocationID).Update(`creation_time`, t).Error
+	if err != nil {
+		return err
+	}
+	return store.cache.SetCreationTime(t)
+}
+
 func (store *gromStore) SaveMessage(seqNum int, msg []byte) error {
+	return store.SaveMessageCtx(context.Background(), seqNum, msg)
+}
+
+// SaveMessageCtx persists msg, bounding the insert (and the duplicate-row check on
+// conflict) by ctx.
+func (store *gromStore) SaveMessageCtx(ctx context.Context, seqNum int, msg []byte) error {
+	db := store.db.WithContext(ctx)
 	s := store.sessionID
 	var testCnt int64
-	store.db.Table("fix_test").Where("open = 1").Count(&testCnt)
+	db.Table("fix_test").Where("open = 1").Count(&testCnt)
 	if testCnt > 1 {
 		return fmt.Errorf("test error")
 	}
-	err := store.db.Exec(`INSERT INTO messages (
+	err := db.Exec(`INSERT INTO messages (
 		msgseqnum, message,
 		beginstring, session_qualifier,
 		sendercompid, sendersubid, senderlocid,
@@ -222,7 +302,7 @@ func (store *gromStore) SaveMessage(seqNum int, msg []byte) error {
 		s.TargetCompID, s.TargetSubID, s.TargetLocationID).Error
 	if err != nil {
 		var counter int64
-		store.db.Table("messages").Where(`beginstring=? AND session_qualifier=?
+		db.Table("messages").Where(`beginstring=? AND session_qualifier=?
 		AND sendercompid=? AND sendersubid=? AND senderlocid=?
 		AND targetcompid=? AND targetsubid=? AND targetlocid=?
 		AND msgseqnum=?`, s.BeginString, s.Qualifier,
@@ -237,10 +317,103 @@ func (store *gromStore) SaveMessage(seqNum int, msg []byte) error {
 	return err
 }
 
-func (store *gromStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+// SaveMessageAndIncrNextSenderMsgSeqNum persists msg and advances the outgoing seqnum
+// in a single transaction so the two never observably diverge on a crash or DB error.
+// Transient errors (SQLite BUSY, MySQL deadlocks, Postgres serialization failures) are
+// retried up to the store's configured txnMaxRetries.
+func (store *gromStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	return store.SaveMessageAndIncrNextSenderMsgSeqNumCtx(context.Background(), seqNum, msg)
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNumCtx is the context-aware form of
+// SaveMessageAndIncrNextSenderMsgSeqNum; ctx bounds each transaction attempt,
+// including retries.
+func (store *gromStore) SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx context.Context, seqNum int, msg []byte) error {
 	s := store.sessionID
+	next := seqNum + 1
+
+	var err error
+	for attempt := 0; attempt <= store.txnMaxRetries; attempt++ {
+		err = store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if txErr := tx.Exec(`INSERT INTO messages (
+				msgseqnum, message,
+				beginstring, session_qualifier,
+				sendercompid, sendersubid, senderlocid,
+				targetcompid, targetsubid, targetlocid)
+				VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, seqNum, string(msg),
+				s.BeginString, s.Qualifier,
+				s.SenderCompID, s.SenderSubID, s.SenderLocationID,
+				s.TargetCompID, s.TargetSubID, s.TargetLocationID).Error; txErr != nil {
+				return txErr
+			}
+
+			return tx.Table(`sessions`).Where(`beginstring=? AND session_qualifier=?
+			AND sendercompid=? AND sendersubid=? AND senderlocid=?
+			AND targetcompid=? AND targetsubid=? AND targetlocid=?`, s.BeginString, s.Qualifier,
+				s.SenderCompID, s.SenderSubID, s.SenderLocationID,
+				s.TargetCompID, s.TargetSubID, s.TargetLocationID).Update(`outgoing_seqnum`, next).Error
+		})
+
+		if err == nil {
+			return store.cache.SetNextSenderMsgSeqNum(next)
+		}
+		if !isRetryableStoreError(err) {
+			return errors.Wrap(err, "gromStore.SaveMessageAndIncrNextSenderMsgSeqNum err")
+		}
+	}
+	return errors.Wrap(err, "gromStore.SaveMessageAndIncrNextSenderMsgSeqNum err")
+}
+
+// isRetryableStoreError reports whether err looks like a transient error worth
+// retrying the enclosing transaction for, e.g. SQLite BUSY, MySQL deadlock (1213),
+// or Postgres serialization failure (40001).
+func isRetryableStoreError(err error) bool {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "database is locked"), strings.Contains(msg, "SQLITE_BUSY"):
+		return true
+	case strings.Contains(msg, "Error 1213"), strings.Contains(msg, "Deadlock found"):
+		return true
+	case strings.Contains(msg, "40001"), strings.Contains(msg, "could not serialize access"):
+		return true
+	default:
+		return false
+	}
+}
+
+// GetMessages is a thin wrapper over IterateMessages kept for backwards compatibility;
+// prefer IterateMessages when beginSeqNum..endSeqNum can be large, since GetMessages
+// buffers every message in the range before returning.
+func (store *gromStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	return store.GetMessagesCtx(context.Background(), beginSeqNum, endSeqNum)
+}
+
+// GetMessagesCtx is the context-aware form of GetMessages; see IterateMessagesCtx for
+// the streaming variant this wraps.
+func (store *gromStore) GetMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int) ([][]byte, error) {
 	var msgs [][]byte
-	rows, err := store.db.Raw(`SELECT message FROM messages
+	err := store.IterateMessagesCtx(ctx, beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		msgs = append(msgs, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// IterateMessages calls fn with each message in [beginSeqNum, endSeqNum], in seqnum
+// order, without buffering the whole range in memory first. Iteration stops at the
+// first error returned by fn or encountered while reading rows.
+func (store *gromStore) IterateMessages(beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return store.IterateMessagesCtx(context.Background(), beginSeqNum, endSeqNum, fn)
+}
+
+// IterateMessagesCtx is the context-aware form of IterateMessages; ctx cancels the
+// query and the row scan loop that follows it.
+func (store *gromStore) IterateMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	s := store.sessionID
+	rows, err := store.db.WithContext(ctx).Raw(`SELECT msgseqnum, message FROM messages
 	WHERE beginstring=? AND session_qualifier=?
 	AND sendercompid=? AND sendersubid=? AND senderlocid=?
 	AND targetcompid=? AND targetsubid=? AND targetlocid=?
@@ -249,29 +422,34 @@ func (store *gromStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error
 		s.SenderCompID, s.SenderSubID, s.SenderLocationID,
 		s.TargetCompID, s.TargetSubID, s.TargetLocationID,
 		beginSeqNum, endSeqNum).Rows()
-
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		var seqNum int
 		var message string
-		if err := rows.Scan(&message); err != nil {
-			return nil, err
+		if err := rows.Scan(&seqNum, &message); err != nil {
+			return err
+		}
+		if err := fn(seqNum, []byte(message)); err != nil {
+			return err
 		}
-		msgs = append(msgs, []byte(message))
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
 	}
-	return msgs, nil
-
+	return rows.Err()
 }
 
 // Close closes the store's database connection
 func (store *gromStore) Close() error {
 	if store.db != nil {
 		db, err := store.db.DB()
-		if err != nil {
+		if err == nil {
 			db.Close()
 		}
 		store.db = nil
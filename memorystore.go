@@ -0,0 +1,205 @@
+package quickfix
+
+import (
+	"context"
+	"time"
+)
+
+type memoryStoreFactory struct{}
+
+// NewMemoryStoreFactory returns a MessageStoreFactory that creates in-memory message
+// stores. Note: messages and seqnums are not persisted across process restarts.
+func NewMemoryStoreFactory() MessageStoreFactory {
+	return memoryStoreFactory{}
+}
+
+func (f memoryStoreFactory) Create(sessionID SessionID) (MessageStore, error) {
+	store := &memoryStore{sessionID: sessionID}
+	return store, store.Reset()
+}
+
+type memoryStore struct {
+	sessionID       SessionID
+	senderMsgSeqNum int
+	targetMsgSeqNum int
+	creationTime    time.Time
+	messages        map[int][]byte
+}
+
+// NextSenderMsgSeqNum returns the next MsgSeqNum that will be sent
+func (store *memoryStore) NextSenderMsgSeqNum() int {
+	return store.senderMsgSeqNum
+}
+
+// NextTargetMsgSeqNum returns the next MsgSeqNum that should be received
+func (store *memoryStore) NextTargetMsgSeqNum() int {
+	return store.targetMsgSeqNum
+}
+
+// SetNextSenderMsgSeqNum sets the next MsgSeqNum that will be sent
+func (store *memoryStore) SetNextSenderMsgSeqNum(next int) error {
+	store.senderMsgSeqNum = next
+	return nil
+}
+
+// SetNextTargetMsgSeqNum sets the next MsgSeqNum that should be received
+func (store *memoryStore) SetNextTargetMsgSeqNum(next int) error {
+	store.targetMsgSeqNum = next
+	return nil
+}
+
+// IncrNextSenderMsgSeqNum increments the next MsgSeqNum that will be sent
+func (store *memoryStore) IncrNextSenderMsgSeqNum() error {
+	store.senderMsgSeqNum++
+	return nil
+}
+
+// IncrNextTargetMsgSeqNum increments the next MsgSeqNum that should be received
+func (store *memoryStore) IncrNextTargetMsgSeqNum() error {
+	store.targetMsgSeqNum++
+	return nil
+}
+
+// CreationTime returns the creation time of the store
+func (store *memoryStore) CreationTime() time.Time {
+	return store.creationTime
+}
+
+// SetCreationTime overrides the store's creation time, e.g. to preserve it across a
+// migration to a different MessageStore backend.
+func (store *memoryStore) SetCreationTime(t time.Time) error {
+	store.creationTime = t
+	return nil
+}
+
+func (store *memoryStore) SaveMessage(seqNum int, msg []byte) error {
+	m := make([]byte, len(msg))
+	copy(m, msg)
+	store.messages[seqNum] = m
+	return nil
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNum persists msg and advances the outgoing seqnum.
+// Both live behind the same struct, so there is no partial-write case to guard
+// against the way there is for a database-backed store; the combined call exists to
+// give memoryStore the same API as the other stores.
+func (store *memoryStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	if err := store.SaveMessage(seqNum, msg); err != nil {
+		return err
+	}
+	return store.SetNextSenderMsgSeqNum(seqNum + 1)
+}
+
+func (store *memoryStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	for seqNum := beginSeqNum; seqNum <= endSeqNum; seqNum++ {
+		if msg, ok := store.messages[seqNum]; ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// IterateMessages calls fn with each stored message in [beginSeqNum, endSeqNum], in
+// seqnum order. There's nothing behind memoryStore worth streaming off of, but it still
+// needs the method to satisfy MessageStore alongside fileStore, mongoStore, and
+// gromStore.
+func (store *memoryStore) IterateMessages(beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	for seqNum := beginSeqNum; seqNum <= endSeqNum; seqNum++ {
+		msg, ok := store.messages[seqNum]
+		if !ok {
+			continue
+		}
+		if err := fn(seqNum, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset deletes the store records and sets the seqnums back to 1
+func (store *memoryStore) Reset() error {
+	store.senderMsgSeqNum = 1
+	store.targetMsgSeqNum = 1
+	store.creationTime = time.Now()
+	store.messages = make(map[int][]byte)
+	return nil
+}
+
+// Refresh is a no-op for memoryStore; there is nothing behind it to reload from
+func (store *memoryStore) Refresh() error {
+	return nil
+}
+
+// Close is a no-op for memoryStore; there is no connection to release
+func (store *memoryStore) Close() error {
+	return nil
+}
+
+// The Ctx variants below honor ctx.Done() before doing any work. None of memoryStore's
+// operations block once started, so there is nothing further to cancel mid-flight.
+
+func (store *memoryStore) SaveMessageCtx(ctx context.Context, seqNum int, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SaveMessage(seqNum, msg)
+}
+
+func (store *memoryStore) SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx context.Context, seqNum int, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SaveMessageAndIncrNextSenderMsgSeqNum(seqNum, msg)
+}
+
+func (store *memoryStore) GetMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return store.GetMessages(beginSeqNum, endSeqNum)
+}
+
+func (store *memoryStore) IterateMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return store.IterateMessages(beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(seqNum, msg)
+	})
+}
+
+func (store *memoryStore) ResetCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.Reset()
+}
+
+func (store *memoryStore) RefreshCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.Refresh()
+}
+
+func (store *memoryStore) SetNextSenderMsgSeqNumCtx(ctx context.Context, next int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetNextSenderMsgSeqNum(next)
+}
+
+func (store *memoryStore) SetNextTargetMsgSeqNumCtx(ctx context.Context, next int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetNextTargetMsgSeqNum(next)
+}
+
+func (store *memoryStore) SetCreationTimeCtx(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.SetCreationTime(t)
+}
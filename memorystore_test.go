@@ -0,0 +1,109 @@
+package quickfix
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestMemoryStore(t *testing.T) *memoryStore {
+	t.Helper()
+	store := &memoryStore{sessionID: SessionID{BeginString: "FIX.4.2", TargetCompID: "TARGET", SenderCompID: "SENDER"}}
+	if err := store.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	return store
+}
+
+func TestMemoryStoreSaveMessageAndIncrNextSenderMsgSeqNum(t *testing.T) {
+	store := newTestMemoryStore(t)
+
+	seqNum := store.NextSenderMsgSeqNum()
+	if err := store.SaveMessageAndIncrNextSenderMsgSeqNum(seqNum, []byte("msg1")); err != nil {
+		t.Fatalf("SaveMessageAndIncrNextSenderMsgSeqNum: %v", err)
+	}
+
+	if got := store.NextSenderMsgSeqNum(); got != seqNum+1 {
+		t.Errorf("NextSenderMsgSeqNum = %d, want %d", got, seqNum+1)
+	}
+	msgs, err := store.GetMessages(seqNum, seqNum)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if len(msgs) != 1 || string(msgs[0]) != "msg1" {
+		t.Errorf("GetMessages = %v, want [msg1]", msgs)
+	}
+}
+
+// TestMemoryStoreSaveMessageAndIncrNextSenderMsgSeqNumCtxCanceled verifies the
+// save-and-increment path leaves the seqnum untouched when its context is already
+// canceled, rather than partially applying the write.
+func TestMemoryStoreSaveMessageAndIncrNextSenderMsgSeqNumCtxCanceled(t *testing.T) {
+	store := newTestMemoryStore(t)
+	seqNum := store.NextSenderMsgSeqNum()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := store.SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx, seqNum, []byte("msg1")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SaveMessageAndIncrNextSenderMsgSeqNumCtx err = %v, want context.Canceled", err)
+	}
+	if got := store.NextSenderMsgSeqNum(); got != seqNum {
+		t.Errorf("NextSenderMsgSeqNum = %d, want unchanged %d", got, seqNum)
+	}
+	if _, ok := store.messages[seqNum]; ok {
+		t.Errorf("message %d was saved despite canceled ctx", seqNum)
+	}
+}
+
+func TestMemoryStoreIterateMessagesOrderAndEarlyStop(t *testing.T) {
+	store := newTestMemoryStore(t)
+	for seqNum := 1; seqNum <= 5; seqNum++ {
+		if err := store.SaveMessage(seqNum, []byte{byte(seqNum)}); err != nil {
+			t.Fatalf("SaveMessage(%d): %v", seqNum, err)
+		}
+	}
+
+	var seen []int
+	err := store.IterateMessages(1, 5, func(seqNum int, msg []byte) error {
+		seen = append(seen, seqNum)
+		if seqNum == 3 {
+			return errors.New("stop here")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "stop here" {
+		t.Fatalf("IterateMessages err = %v, want 'stop here'", err)
+	}
+	want := []int{1, 2, 3}
+	if len(seen) != len(want) {
+		t.Fatalf("IterateMessages visited %v, want %v", seen, want)
+	}
+	for i, seqNum := range want {
+		if seen[i] != seqNum {
+			t.Errorf("IterateMessages visited[%d] = %d, want %d", i, seen[i], seqNum)
+		}
+	}
+}
+
+func TestMemoryStoreIterateMessagesCtxCanceled(t *testing.T) {
+	store := newTestMemoryStore(t)
+	if err := store.SaveMessage(1, []byte("msg1")); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := store.IterateMessagesCtx(ctx, 1, 1, func(seqNum int, msg []byte) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("IterateMessagesCtx err = %v, want context.Canceled", err)
+	}
+	if called {
+		t.Errorf("fn was called despite canceled ctx")
+	}
+}
@@ -0,0 +1,373 @@
+package quickfix
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/quickfixgo/quickfix/config"
+)
+
+type mongoStoreFactory struct {
+	settings *Settings
+}
+
+// NewMongoStoreFactory returns a MessageStoreFactory backed by MongoDB, configured via
+// the MongoStoreConnection and MongoStoreDatabase settings.
+func NewMongoStoreFactory(settings *Settings) MessageStoreFactory {
+	return mongoStoreFactory{settings: settings}
+}
+
+func (f mongoStoreFactory) Create(sessionID SessionID) (MessageStore, error) {
+	connection, err := f.settings.GlobalSettings().Setting(config.MongoStoreConnection)
+	if err != nil {
+		return nil, err
+	}
+	database, err := f.settings.GlobalSettings().Setting(config.MongoStoreDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	txnMaxRetries := defaultStoreTxnMaxRetries
+	if f.settings.GlobalSettings().HasSetting(config.StoreTransactionMaxRetries) {
+		n, err := f.settings.GlobalSettings().IntSetting(config.StoreTransactionMaxRetries)
+		if err != nil {
+			return nil, err
+		}
+		txnMaxRetries = n
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(connection))
+	if err != nil {
+		return nil, errors.Wrap(err, "mongoStoreFactory.Create err")
+	}
+
+	store := &mongoStore{
+		sessionID:     sessionID,
+		cache:         &memoryStore{},
+		client:        client,
+		db:            client.Database(database),
+		txnMaxRetries: txnMaxRetries,
+	}
+	if err := store.cache.Reset(); err != nil {
+		return nil, errors.Wrap(err, "cache reset")
+	}
+	if err := store.populateCache(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+type mongoStore struct {
+	sessionID     SessionID
+	cache         *memoryStore
+	client        *mongo.Client
+	db            *mongo.Database
+	txnMaxRetries int
+}
+
+// Ping reports whether the store's mongo client can reach its deployment within ctx,
+// so it can be health-probed by WithStoreObserver the same as gromStore.
+func (store *mongoStore) Ping(ctx context.Context) error {
+	return store.client.Ping(ctx, nil)
+}
+
+func (store *mongoStore) sessionsColl() *mongo.Collection {
+	return store.db.Collection("sessions")
+}
+
+func (store *mongoStore) messagesColl() *mongo.Collection {
+	return store.db.Collection("messages")
+}
+
+// filter identifies this store's session across both the sessions and messages
+// collections.
+func (store *mongoStore) filter() bson.M {
+	s := store.sessionID
+	return bson.M{
+		"begin_string":       s.BeginString,
+		"session_qualifier":  s.Qualifier,
+		"sender_comp_id":     s.SenderCompID,
+		"sender_sub_id":      s.SenderSubID,
+		"sender_location_id": s.SenderLocationID,
+		"target_comp_id":     s.TargetCompID,
+		"target_sub_id":      s.TargetSubID,
+		"target_location_id": s.TargetLocationID,
+	}
+}
+
+type mongoSessionDoc struct {
+	CreationTime   time.Time `bson:"creation_time"`
+	IncomingSeqNum int       `bson:"incoming_seqnum"`
+	OutgoingSeqNum int       `bson:"outgoing_seqnum"`
+}
+
+func (store *mongoStore) populateCache() error {
+	ctx := context.Background()
+	var dest mongoSessionDoc
+	err := store.sessionsColl().FindOne(ctx, store.filter()).Decode(&dest)
+	if err == nil {
+		store.cache.creationTime = dest.CreationTime
+		if err = store.cache.SetNextTargetMsgSeqNum(dest.IncomingSeqNum); err != nil {
+			return errors.Wrap(err, "cache set next target")
+		}
+		if err = store.cache.SetNextSenderMsgSeqNum(dest.OutgoingSeqNum); err != nil {
+			return errors.Wrap(err, "cache set next sender")
+		}
+		return nil
+	}
+	if err == mongo.ErrNoDocuments {
+		doc := store.sessionDoc()
+		_, err = store.sessionsColl().InsertOne(ctx, doc)
+		return err
+	}
+	return err
+}
+
+func (store *mongoStore) sessionDoc() bson.M {
+	doc := store.filter()
+	doc["creation_time"] = store.cache.CreationTime()
+	doc["incoming_seqnum"] = store.cache.NextTargetMsgSeqNum()
+	doc["outgoing_seqnum"] = store.cache.NextSenderMsgSeqNum()
+	return doc
+}
+
+// NextSenderMsgSeqNum returns the next MsgSeqNum that will be sent
+func (store *mongoStore) NextSenderMsgSeqNum() int {
+	return store.cache.NextSenderMsgSeqNum()
+}
+
+// NextTargetMsgSeqNum returns the next MsgSeqNum that should be received
+func (store *mongoStore) NextTargetMsgSeqNum() int {
+	return store.cache.NextTargetMsgSeqNum()
+}
+
+// SetNextSenderMsgSeqNum sets the next MsgSeqNum that will be sent
+func (store *mongoStore) SetNextSenderMsgSeqNum(next int) error {
+	return store.SetNextSenderMsgSeqNumCtx(context.Background(), next)
+}
+
+// SetNextTargetMsgSeqNum sets the next MsgSeqNum that should be received
+func (store *mongoStore) SetNextTargetMsgSeqNum(next int) error {
+	return store.SetNextTargetMsgSeqNumCtx(context.Background(), next)
+}
+
+// IncrNextSenderMsgSeqNum increments the next MsgSeqNum that will be sent
+func (store *mongoStore) IncrNextSenderMsgSeqNum() error {
+	if err := store.cache.IncrNextSenderMsgSeqNum(); err != nil {
+		return errors.Wrap(err, "cache incr next")
+	}
+	return store.SetNextSenderMsgSeqNum(store.cache.NextSenderMsgSeqNum())
+}
+
+// IncrNextTargetMsgSeqNum increments the next MsgSeqNum that should be received
+func (store *mongoStore) IncrNextTargetMsgSeqNum() error {
+	if err := store.cache.IncrNextTargetMsgSeqNum(); err != nil {
+		return errors.Wrap(err, "cache incr next")
+	}
+	return store.SetNextTargetMsgSeqNum(store.cache.NextTargetMsgSeqNum())
+}
+
+// CreationTime returns the creation time of the store
+func (store *mongoStore) CreationTime() time.Time {
+	return store.cache.CreationTime()
+}
+
+// SetCreationTime overrides the store's creation time, e.g. to preserve it across a
+// migration to a different MessageStore backend.
+func (store *mongoStore) SetCreationTime(t time.Time) error {
+	return store.SetCreationTimeCtx(context.Background(), t)
+}
+
+func (store *mongoStore) SaveMessage(seqNum int, msg []byte) error {
+	return store.SaveMessageCtx(context.Background(), seqNum, msg)
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNum persists msg and advances the outgoing seqnum
+// inside a single client session transaction, so the two are never observed apart;
+// this requires the backing MongoDB deployment to be a replica set.
+func (store *mongoStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	return store.SaveMessageAndIncrNextSenderMsgSeqNumCtx(context.Background(), seqNum, msg)
+}
+
+// isRetryableMongoTxnError reports whether err looks like a transient transaction
+// error worth retrying, per the driver's own TransientTransactionError label (the mongo
+// equivalent of isRetryableStoreError's SQLite-BUSY/MySQL-deadlock/Postgres-40001
+// checks for the gorm backend).
+func isRetryableMongoTxnError(err error) bool {
+	labeled, ok := err.(mongo.ServerError)
+	return ok && labeled.HasErrorLabel("TransientTransactionError")
+}
+
+func (store *mongoStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	return store.GetMessagesCtx(context.Background(), beginSeqNum, endSeqNum)
+}
+
+type mongoMessageDoc struct {
+	MsgSeqNum int    `bson:"msgseqnum"`
+	Message   []byte `bson:"message"`
+}
+
+// IterateMessages streams each message in [beginSeqNum, endSeqNum] off a mongo cursor,
+// in seqnum order, handing each one to fn as it's read rather than decoding the whole
+// range into memory up front.
+func (store *mongoStore) IterateMessages(beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return store.IterateMessagesCtx(context.Background(), beginSeqNum, endSeqNum, fn)
+}
+
+// Reset deletes the store records and sets the seqnums back to 1
+func (store *mongoStore) Reset() error {
+	return store.ResetCtx(context.Background())
+}
+
+// Refresh reloads the store from the database
+func (store *mongoStore) Refresh() error {
+	return store.RefreshCtx(context.Background())
+}
+
+// Close disconnects the store's mongo client
+func (store *mongoStore) Close() error {
+	if store.client == nil {
+		return nil
+	}
+	err := store.client.Disconnect(context.Background())
+	store.client = nil
+	return err
+}
+
+// The Ctx variants below thread ctx into the mongo driver calls directly, so
+// cancellation reaches the in-flight query instead of only being checked beforehand.
+
+func (store *mongoStore) SaveMessageCtx(ctx context.Context, seqNum int, msg []byte) error {
+	filter := store.filter()
+	filter["msgseqnum"] = seqNum
+	_, err := store.messagesColl().UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"message": msg}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// SaveMessageAndIncrNextSenderMsgSeqNumCtx is the context-aware form of
+// SaveMessageAndIncrNextSenderMsgSeqNum; ctx bounds each transaction attempt, including
+// retries. A transaction that fails with TransientTransactionError (e.g. a write
+// conflict with another writer) is retried up to the store's configured
+// txnMaxRetries, mirroring gromStore's retry loop for the same operation.
+func (store *mongoStore) SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx context.Context, seqNum int, msg []byte) error {
+	next := seqNum + 1
+
+	sess, err := store.client.StartSession()
+	if err != nil {
+		return errors.Wrap(err, "mongoStore.SaveMessageAndIncrNextSenderMsgSeqNumCtx err")
+	}
+	defer sess.EndSession(ctx)
+
+	for attempt := 0; attempt <= store.txnMaxRetries; attempt++ {
+		_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+			msgFilter := store.filter()
+			msgFilter["msgseqnum"] = seqNum
+			if _, err := store.messagesColl().UpdateOne(sessCtx, msgFilter,
+				bson.M{"$set": bson.M{"message": msg}}, options.Update().SetUpsert(true)); err != nil {
+				return nil, err
+			}
+			_, err := store.sessionsColl().UpdateOne(sessCtx, store.filter(), bson.M{"$set": bson.M{"outgoing_seqnum": next}})
+			return nil, err
+		})
+
+		if err == nil {
+			return store.cache.SetNextSenderMsgSeqNum(next)
+		}
+		if !isRetryableMongoTxnError(err) {
+			return errors.Wrap(err, "mongoStore.SaveMessageAndIncrNextSenderMsgSeqNumCtx err")
+		}
+	}
+	return errors.Wrap(err, "mongoStore.SaveMessageAndIncrNextSenderMsgSeqNumCtx err")
+}
+
+func (store *mongoStore) GetMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	err := store.IterateMessagesCtx(ctx, beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		msgs = append(msgs, msg)
+		return nil
+	})
+	return msgs, err
+}
+
+func (store *mongoStore) IterateMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	filter := store.filter()
+	filter["msgseqnum"] = bson.M{"$gte": beginSeqNum, "$lte": endSeqNum}
+
+	cursor, err := store.messagesColl().Find(ctx, filter, options.Find().SetSort(bson.M{"msgseqnum": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc mongoMessageDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc.MsgSeqNum, doc.Message); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (store *mongoStore) ResetCtx(ctx context.Context) error {
+	if _, err := store.messagesColl().DeleteMany(ctx, store.filter()); err != nil {
+		return err
+	}
+	if err := store.cache.Reset(); err != nil {
+		return err
+	}
+	_, err := store.sessionsColl().UpdateOne(ctx, store.filter(), bson.M{"$set": bson.M{
+		"creation_time":   store.cache.CreationTime(),
+		"incoming_seqnum": store.cache.NextTargetMsgSeqNum(),
+		"outgoing_seqnum": store.cache.NextSenderMsgSeqNum(),
+	}})
+	return err
+}
+
+func (store *mongoStore) RefreshCtx(ctx context.Context) error {
+	if err := store.cache.Reset(); err != nil {
+		return err
+	}
+	var dest mongoSessionDoc
+	err := store.sessionsColl().FindOne(ctx, store.filter()).Decode(&dest)
+	if err != nil {
+		return err
+	}
+	store.cache.creationTime = dest.CreationTime
+	if err := store.cache.SetNextTargetMsgSeqNum(dest.IncomingSeqNum); err != nil {
+		return errors.Wrap(err, "cache set next target")
+	}
+	return store.cache.SetNextSenderMsgSeqNum(dest.OutgoingSeqNum)
+}
+
+func (store *mongoStore) SetNextSenderMsgSeqNumCtx(ctx context.Context, next int) error {
+	_, err := store.sessionsColl().UpdateOne(ctx, store.filter(), bson.M{"$set": bson.M{"outgoing_seqnum": next}})
+	if err != nil {
+		return err
+	}
+	return store.cache.SetNextSenderMsgSeqNum(next)
+}
+
+func (store *mongoStore) SetNextTargetMsgSeqNumCtx(ctx context.Context, next int) error {
+	_, err := store.sessionsColl().UpdateOne(ctx, store.filter(), bson.M{"$set": bson.M{"incoming_seqnum": next}})
+	if err != nil {
+		return err
+	}
+	return store.cache.SetNextTargetMsgSeqNum(next)
+}
+
+func (store *mongoStore) SetCreationTimeCtx(ctx context.Context, t time.Time) error {
+	_, err := store.sessionsColl().UpdateOne(ctx, store.filter(), bson.M{"$set": bson.M{"creation_time": t}})
+	if err != nil {
+		return err
+	}
+	return store.cache.SetCreationTime(t)
+}
@@ -0,0 +1,73 @@
+package quickfix
+
+import (
+	"context"
+	"time"
+)
+
+// session owns a single FIX session's message store and outgoing seqnum. Connection
+// handling, admin message processing, and the session state machine live alongside
+// this in the rest of the session_*.go files; this file covers persisting and
+// replaying messages through the store.
+type session struct {
+	sessionID          SessionID
+	store              MessageStore
+	storeOpTimeout     time.Duration
+	storeReplayTimeout time.Duration
+}
+
+// storeOpContext derives a context for a single MessageStore operation, bounded by the
+// session's configured StoreOperationTimeout (or unbounded if it isn't set), so a
+// stalled store doesn't block the session goroutine forever. The context derives from
+// context.Background, so only the timeout (or the returned cancel) ends it.
+func (s *session) storeOpContext() (context.Context, context.CancelFunc) {
+	if s.storeOpTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.storeOpTimeout)
+}
+
+// queueForSend assigns msg the next outgoing seqnum and persists it, advancing the
+// seqnum in the same MessageStore call so the store never observes only one half of
+// the pair on a crash or DB error.
+func (s *session) queueForSend(msg *Message) error {
+	seqNum := s.store.NextSenderMsgSeqNum()
+	msg.Header.SetField(tagMsgSeqNum, FIXInt(seqNum))
+
+	ctx, cancel := s.storeOpContext()
+	defer cancel()
+
+	return s.store.SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx, seqNum, []byte(msg.String()))
+}
+
+// storeReplayContext derives a context for a whole resend replay, bounded by
+// the session's configured StoreReplayTimeout (or unbounded if it isn't set). This is
+// deliberately separate from storeOpContext: StoreOperationTimeout is sized for a
+// single MessageStore call, and reusing it here would abort a wide ResendRequest
+// partway through a long-lived session's replay instead of just a slow individual read.
+func (s *session) storeReplayContext() (context.Context, context.CancelFunc) {
+	if s.storeReplayTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.storeReplayTimeout)
+}
+
+// resendRange replays every message in [beginSeqNum, endSeqNum] in response to a
+// ResendRequest, bounding the whole replay by a single replay context derived from
+// StoreReplayTimeout. It hands each message to send as IterateMessagesCtx yields it
+// instead of buffering the whole range, so a wide resend
+// request can't force the session to hold hundreds of MB of backlog in memory at once.
+func (s *session) resendRange(beginSeqNum, endSeqNum int) error {
+	ctx, cancel := s.storeReplayContext()
+	defer cancel()
+
+	return s.store.IterateMessagesCtx(ctx, beginSeqNum, endSeqNum, func(seqNum int, msg []byte) error {
+		return s.send(msg)
+	})
+}
+
+// send is where resendRange hands each replayed message. session holds no connection,
+// so send writes raw nowhere and always returns nil.
+func (s *session) send(raw []byte) error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package quickfix
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/quickfixgo/quickfix/config"
+)
+
+// MessageStoreDriverFactoryFunc builds a MessageStoreFactory from Settings. It is the
+// shape registered with RegisterMessageStoreDriver.
+type MessageStoreDriverFactoryFunc func(settings *Settings) (MessageStoreFactory, error)
+
+var storeDriversLock sync.RWMutex
+var storeDrivers = make(map[string]MessageStoreDriverFactoryFunc)
+
+// RegisterMessageStoreDriver registers a MessageStoreFactory constructor under name so
+// it can be selected from a config file via the MessageStoreType setting, instead of
+// requiring callers to pick a NewXStoreFactory constructor in code.
+func RegisterMessageStoreDriver(name string, factoryFn MessageStoreDriverFactoryFunc) {
+	storeDriversLock.Lock()
+	defer storeDriversLock.Unlock()
+	storeDrivers[name] = factoryFn
+}
+
+func init() {
+	RegisterMessageStoreDriver("memory", func(settings *Settings) (MessageStoreFactory, error) {
+		return NewMemoryStoreFactory(), nil
+	})
+	RegisterMessageStoreDriver("file", func(settings *Settings) (MessageStoreFactory, error) {
+		return NewFileStoreFactory(settings), nil
+	})
+	RegisterMessageStoreDriver("mongo", func(settings *Settings) (MessageStoreFactory, error) {
+		return NewMongoStoreFactory(settings), nil
+	})
+	RegisterMessageStoreDriver("gorm", newGormStoreDriver)
+	RegisterMessageStoreDriver("sql", newGormStoreDriver)
+}
+
+// newGormStoreDriver opens the *gorm.DB named by config.GormStoreDriver/
+// config.GormStoreDataSourceName and wraps it in a gormStoreFactory, so a
+// `MessageStoreType=gorm` (or `sql`) session file is enough to select the store without
+// the caller constructing a *gorm.DB itself.
+func newGormStoreDriver(settings *Settings) (MessageStoreFactory, error) {
+	driver, err := settings.GlobalSettings().Setting(config.GormStoreDriver)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := settings.GlobalSettings().Setting(config.GormStoreDataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialector gorm.Dialector
+	switch driver {
+	case "sqlite", "sqlite3":
+		dialector = sqlite.Open(dsn)
+	case "mysql":
+		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported %s: %s", config.GormStoreDriver, driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, errors.Wrap(err, "newGormStoreDriver open err")
+	}
+
+	return NewGormStoreFactory(settings, db), nil
+}
+
+// NewMessageStoreFactory builds a MessageStoreFactory from settings' MessageStoreType
+// setting, dispatching to whichever driver was registered under that name (built-in or
+// via RegisterMessageStoreDriver).
+func NewMessageStoreFactory(settings *Settings) (MessageStoreFactory, error) {
+	storeType, err := settings.GlobalSettings().Setting(config.MessageStoreType)
+	if err != nil {
+		return nil, err
+	}
+
+	storeDriversLock.RLock()
+	factoryFn, ok := storeDrivers[storeType]
+	storeDriversLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown %s: %s", config.MessageStoreType, storeType)
+	}
+
+	return factoryFn(settings)
+}
@@ -0,0 +1,252 @@
+package quickfix
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/quickfixgo/quickfix/config"
+)
+
+// storePinger is implemented by MessageStore backends that have something worth
+// health-probing, i.e. a connection to a backing service. gromStore implements it via
+// its *sql.DB; memoryStore and fileStore don't, since there's no remote dependency to
+// go unhealthy, and are simply never probed.
+type storePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// WithStoreObserver returns a MessageStoreFactory that reports to observer every save,
+// read, reset, refresh, seqnum update and creation-time update of any store factory
+// creates (NextSenderMsgSeqNum, NextTargetMsgSeqNum, CreationTime and Close are not
+// reported), and, for sessions whose store implements
+// storePinger, runs a periodic health probe (config.StoreHealthProbeInterval) that also
+// reports to observer. This is the one way to get StoreObserver coverage regardless of
+// backend -- memory, file, mongo, or gorm -- rather than each MessageStoreFactory
+// wiring its own timing and probe plumbing.
+func WithStoreObserver(factory MessageStoreFactory, settings *Settings, observer StoreObserver) MessageStoreFactory {
+	return observedStoreFactory{inner: factory, settings: settings, observer: observer}
+}
+
+type observedStoreFactory struct {
+	inner    MessageStoreFactory
+	settings *Settings
+	observer StoreObserver
+}
+
+func (f observedStoreFactory) Create(sessionID SessionID) (MessageStore, error) {
+	inner, err := f.inner.Create(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &observedStore{sessionID: sessionID, store: inner, observer: f.observer}
+
+	pinger, ok := inner.(storePinger)
+	if ok && f.settings.GlobalSettings().HasSetting(config.StoreHealthProbeInterval) {
+		interval, err := f.settings.GlobalSettings().IntSetting(config.StoreHealthProbeInterval)
+		if err != nil {
+			return nil, err
+		}
+		if interval > 0 {
+			store.startHealthProbe(pinger, time.Duration(interval)*time.Second)
+		}
+	}
+
+	return store, nil
+}
+
+// observedStore wraps another MessageStore, reporting each observed operation's
+// duration and result to observer under the method's name without its Ctx suffix
+// (SaveMessage, IterateMessages, Reset, ...), so a call and its Ctx form are counted
+// under the same op.
+type observedStore struct {
+	sessionID SessionID
+	store     MessageStore
+	observer  StoreObserver
+	stopProbe chan struct{}
+	probeDone sync.WaitGroup
+}
+
+func (s *observedStore) observe(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	s.observer.OnStoreOp(s.sessionID, op, time.Since(start), err)
+	return err
+}
+
+// startHealthProbe pings pinger every interval and reports transitions to the observer.
+// Close waits on probeDone before returning, so the probe goroutine never runs against
+// a closed store.
+func (s *observedStore) startHealthProbe(pinger storePinger, interval time.Duration) {
+	s.stopProbe = make(chan struct{})
+	s.probeDone.Add(1)
+	go func() {
+		defer s.probeDone.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastHealthy := true
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				healthy := pinger.Ping(ctx) == nil
+				cancel()
+				if healthy != lastHealthy {
+					s.observer.OnStoreHealth(s.sessionID, healthy)
+					lastHealthy = healthy
+				}
+			case <-s.stopProbe:
+				return
+			}
+		}
+	}()
+}
+
+func (s *observedStore) NextSenderMsgSeqNum() int {
+	return s.store.NextSenderMsgSeqNum()
+}
+
+func (s *observedStore) NextTargetMsgSeqNum() int {
+	return s.store.NextTargetMsgSeqNum()
+}
+
+func (s *observedStore) SetNextSenderMsgSeqNum(next int) error {
+	return s.observe("SetNextSenderMsgSeqNum", func() error {
+		return s.store.SetNextSenderMsgSeqNum(next)
+	})
+}
+
+func (s *observedStore) SetNextSenderMsgSeqNumCtx(ctx context.Context, next int) error {
+	return s.observe("SetNextSenderMsgSeqNum", func() error {
+		return s.store.SetNextSenderMsgSeqNumCtx(ctx, next)
+	})
+}
+
+func (s *observedStore) SetNextTargetMsgSeqNum(next int) error {
+	return s.observe("SetNextTargetMsgSeqNum", func() error {
+		return s.store.SetNextTargetMsgSeqNum(next)
+	})
+}
+
+func (s *observedStore) SetNextTargetMsgSeqNumCtx(ctx context.Context, next int) error {
+	return s.observe("SetNextTargetMsgSeqNum", func() error {
+		return s.store.SetNextTargetMsgSeqNumCtx(ctx, next)
+	})
+}
+
+func (s *observedStore) IncrNextSenderMsgSeqNum() error {
+	return s.observe("IncrNextSenderMsgSeqNum", func() error {
+		return s.store.IncrNextSenderMsgSeqNum()
+	})
+}
+
+func (s *observedStore) IncrNextTargetMsgSeqNum() error {
+	return s.observe("IncrNextTargetMsgSeqNum", func() error {
+		return s.store.IncrNextTargetMsgSeqNum()
+	})
+}
+
+func (s *observedStore) CreationTime() time.Time {
+	return s.store.CreationTime()
+}
+
+func (s *observedStore) SetCreationTime(t time.Time) error {
+	return s.observe("SetCreationTime", func() error { return s.store.SetCreationTime(t) })
+}
+
+func (s *observedStore) SetCreationTimeCtx(ctx context.Context, t time.Time) error {
+	return s.observe("SetCreationTime", func() error { return s.store.SetCreationTimeCtx(ctx, t) })
+}
+
+func (s *observedStore) SaveMessage(seqNum int, msg []byte) error {
+	return s.observe("SaveMessage", func() error {
+		return s.store.SaveMessage(seqNum, msg)
+	})
+}
+
+func (s *observedStore) SaveMessageCtx(ctx context.Context, seqNum int, msg []byte) error {
+	return s.observe("SaveMessage", func() error {
+		return s.store.SaveMessageCtx(ctx, seqNum, msg)
+	})
+}
+
+func (s *observedStore) SaveMessageAndIncrNextSenderMsgSeqNum(seqNum int, msg []byte) error {
+	return s.observe("SaveMessageAndIncrNextSenderMsgSeqNum", func() error {
+		return s.store.SaveMessageAndIncrNextSenderMsgSeqNum(seqNum, msg)
+	})
+}
+
+func (s *observedStore) SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx context.Context, seqNum int, msg []byte) error {
+	return s.observe("SaveMessageAndIncrNextSenderMsgSeqNum", func() error {
+		return s.store.SaveMessageAndIncrNextSenderMsgSeqNumCtx(ctx, seqNum, msg)
+	})
+}
+
+func (s *observedStore) GetMessages(beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	err := s.observe("GetMessages", func() error {
+		var err error
+		msgs, err = s.store.GetMessages(beginSeqNum, endSeqNum)
+		return err
+	})
+	return msgs, err
+}
+
+func (s *observedStore) GetMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int) ([][]byte, error) {
+	var msgs [][]byte
+	err := s.observe("GetMessages", func() error {
+		var err error
+		msgs, err = s.store.GetMessagesCtx(ctx, beginSeqNum, endSeqNum)
+		return err
+	})
+	return msgs, err
+}
+
+func (s *observedStore) IterateMessages(beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return s.observe("IterateMessages", func() error {
+		return s.store.IterateMessages(beginSeqNum, endSeqNum, fn)
+	})
+}
+
+func (s *observedStore) IterateMessagesCtx(ctx context.Context, beginSeqNum, endSeqNum int, fn func(seqNum int, msg []byte) error) error {
+	return s.observe("IterateMessages", func() error {
+		return s.store.IterateMessagesCtx(ctx, beginSeqNum, endSeqNum, fn)
+	})
+}
+
+func (s *observedStore) Reset() error {
+	return s.observe("Reset", func() error {
+		return s.store.Reset()
+	})
+}
+
+func (s *observedStore) ResetCtx(ctx context.Context) error {
+	return s.observe("Reset", func() error {
+		return s.store.ResetCtx(ctx)
+	})
+}
+
+func (s *observedStore) Refresh() error {
+	return s.observe("Refresh", func() error {
+		return s.store.Refresh()
+	})
+}
+
+func (s *observedStore) RefreshCtx(ctx context.Context) error {
+	return s.observe("Refresh", func() error {
+		return s.store.RefreshCtx(ctx)
+	})
+}
+
+// Close stops the health probe (if one was started) before closing the wrapped store,
+// so the probe goroutine never calls Ping against a store that's already being torn
+// down.
+func (s *observedStore) Close() error {
+	if s.stopProbe != nil {
+		close(s.stopProbe)
+		s.stopProbe = nil
+		s.probeDone.Wait()
+	}
+	return s.store.Close()
+}
@@ -0,0 +1,14 @@
+package quickfix
+
+import "time"
+
+// StoreObserver receives MessageStore operation and health events so operators can
+// tell whether a slow FIX session is caused by the database or the counterparty.
+type StoreObserver interface {
+	// OnStoreOp is called after every observed MessageStore operation completes,
+	// successfully or not. The seqnum and creation-time getters and Close are not
+	// observed.
+	OnStoreOp(sessionID SessionID, op string, duration time.Duration, err error)
+	// OnStoreHealth is called whenever the periodic store probe's health signal changes.
+	OnStoreHealth(sessionID SessionID, healthy bool)
+}
@@ -0,0 +1,56 @@
+package quickfix
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusStoreObserver is a StoreObserver that exports per-session MessageStore
+// operation counts, latencies, and health as prometheus metrics.
+type PrometheusStoreObserver struct {
+	opsTotal   *prometheus.CounterVec
+	opDuration *prometheus.HistogramVec
+	health     *prometheus.GaugeVec
+}
+
+// NewPrometheusStoreObserver creates a PrometheusStoreObserver and registers its
+// collectors with reg (pass prometheus.DefaultRegisterer to use the default registry).
+func NewPrometheusStoreObserver(reg prometheus.Registerer) *PrometheusStoreObserver {
+	o := &PrometheusStoreObserver{
+		opsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickfix_store_ops_total",
+			Help: "Total MessageStore operations, by op, session, and result.",
+		}, []string{"op", "session", "result"}),
+		opDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "quickfix_store_op_duration_seconds",
+			Help: "MessageStore operation latency in seconds, by op and session.",
+		}, []string{"op", "session"}),
+		health: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "quickfix_store_healthy",
+			Help: "1 if the store's last health probe succeeded, 0 otherwise, by session.",
+		}, []string{"session"}),
+	}
+	reg.MustRegister(o.opsTotal, o.opDuration, o.health)
+	return o
+}
+
+// OnStoreOp implements StoreObserver.
+func (o *PrometheusStoreObserver) OnStoreOp(sessionID SessionID, op string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	session := sessionID.String()
+	o.opsTotal.WithLabelValues(op, session, result).Inc()
+	o.opDuration.WithLabelValues(op, session).Observe(duration.Seconds())
+}
+
+// OnStoreHealth implements StoreObserver.
+func (o *PrometheusStoreObserver) OnStoreHealth(sessionID SessionID, healthy bool) {
+	v := 0.0
+	if healthy {
+		v = 1.0
+	}
+	o.health.WithLabelValues(sessionID.String()).Set(v)
+}